@@ -0,0 +1,158 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// DownloadOptions configures a single GetStreamContextWithOptions call.
+type DownloadOptions struct {
+	// ResumeKey identifies a previously interrupted download so chunks
+	// already recorded as complete are skipped instead of re-fetched.
+	ResumeKey *ResumeKey
+
+	// ChunkStore persists chunk completion state for ResumeKey. Defaults to
+	// a fileChunkStore sidecar next to ResumeKey.OutputPath.
+	ChunkStore ChunkStore
+
+	// ChunkRetries is how many times a single failed chunk is retried, with
+	// backoff, before the whole download is aborted. Default 0 preserves
+	// the old all-or-nothing behaviour.
+	ChunkRetries int
+
+	// ProgressCallback overrides Client.ProgressCallback for this call.
+	ProgressCallback ProgressFunc
+}
+
+// ResumeKey identifies a chunked download for resume purposes.
+type ResumeKey struct {
+	VideoID       string
+	Itag          int
+	ContentLength int64
+	ETag          string
+
+	// OutputPath is where the caller is writing the downloaded bytes. The
+	// default fileChunkStore keeps its sidecar state next to this path.
+	OutputPath string
+}
+
+// ChunkStore tracks which chunks of a chunked download have already been
+// written to disk, so GetStreamContextWithOptions can resume an interrupted
+// download instead of starting over. It only tracks completion, not chunk
+// bytes: downloadChunked hands every chunk's data to the caller over
+// senderChan same as it always has, and the caller remains responsible for
+// writing it to the output file itself.
+type ChunkStore interface {
+	// Completed returns the chunk indexes already recorded as written for
+	// videoID/itag.
+	Completed(videoID string, itag int) ([]int, error)
+
+	// MarkComplete records that chunkIdx has been fully written.
+	MarkComplete(videoID string, itag int, chunkIdx int) error
+}
+
+// fileChunkStore is the default ChunkStore, recording completed chunk
+// indexes in a JSON sidecar file next to the download's output path.
+type fileChunkStore struct {
+	statePath string
+
+	mu    sync.Mutex
+	state chunkStoreState
+}
+
+type chunkStoreState struct {
+	Completed map[string][]int `json:"completed"`
+}
+
+// NewFileChunkStore returns a ChunkStore backed by an "<outputPath>.state.json"
+// sidecar file, loading any state left over from a previous, interrupted run.
+func NewFileChunkStore(outputPath string) (ChunkStore, error) {
+	s := &fileChunkStore{
+		statePath: outputPath + ".state.json",
+		state:     chunkStoreState{Completed: map[string][]int{}},
+	}
+
+	data, err := os.ReadFile(s.statePath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &s.state); err != nil {
+			return nil, fmt.Errorf("parse chunk store state: %w", err)
+		}
+	case os.IsNotExist(err):
+		// no previous run to resume from
+	default:
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func chunkStoreKey(videoID string, itag int) string {
+	return fmt.Sprintf("%s:%d", videoID, itag)
+}
+
+func (s *fileChunkStore) Completed(videoID string, itag int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]int(nil), s.state.Completed[chunkStoreKey(videoID, itag)]...), nil
+}
+
+func (s *fileChunkStore) MarkComplete(videoID string, itag int, chunkIdx int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := chunkStoreKey(videoID, itag)
+	s.state.Completed[key] = append(s.state.Completed[key], chunkIdx)
+
+	return s.persist()
+}
+
+// verifyResumeKey checks key's recorded ContentLength/ETag against the
+// format currently being downloaded before its sidecar completed-chunk state
+// is trusted. Without this, a stale .state.json left over from a different
+// encode of the same itag (YouTube reissued the format URL, or the remote
+// file simply changed) would get silently resumed against, splicing bytes
+// from two different encodes into one output file.
+func verifyResumeKey(ctx context.Context, c *Client, req *http.Request, format *Format, key *ResumeKey) error {
+	if key.ContentLength != 0 && key.ContentLength != format.ContentLength {
+		return fmt.Errorf("resume key content-length %d does not match format content-length %d", key.ContentLength, format.ContentLength)
+	}
+
+	if key.ETag == "" {
+		return nil
+	}
+
+	headReq := req.Clone(ctx)
+	headReq.Method = http.MethodHead
+
+	resp, err := c.httpDo(headReq)
+	if err != nil {
+		return fmt.Errorf("verify resume key etag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if etag := resp.Header.Get("ETag"); etag != "" && etag != key.ETag {
+		return fmt.Errorf("resume key etag %q does not match current etag %q", key.ETag, etag)
+	}
+
+	return nil
+}
+
+func (s *fileChunkStore) persist() error {
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.statePath)
+}
@@ -0,0 +1,85 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileChunkStoreMarkCompleteAndCompleted(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "video.mp4")
+
+	store, err := NewFileChunkStore(statePath)
+	if err != nil {
+		t.Fatalf("NewFileChunkStore: %v", err)
+	}
+
+	if err := store.MarkComplete("abc", 137, 0); err != nil {
+		t.Fatalf("MarkComplete: %v", err)
+	}
+	if err := store.MarkComplete("abc", 137, 2); err != nil {
+		t.Fatalf("MarkComplete: %v", err)
+	}
+
+	done, err := store.Completed("abc", 137)
+	if err != nil {
+		t.Fatalf("Completed: %v", err)
+	}
+	if len(done) != 2 || done[0] != 0 || done[1] != 2 {
+		t.Errorf("Completed(abc, 137) = %v, want [0 2]", done)
+	}
+
+	// A different itag for the same video must not see chunks completed
+	// under another itag.
+	other, err := store.Completed("abc", 136)
+	if err != nil {
+		t.Fatalf("Completed: %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("Completed(abc, 136) = %v, want none", other)
+	}
+
+	// Re-opening the store picks up what was persisted to the sidecar file.
+	reopened, err := NewFileChunkStore(statePath)
+	if err != nil {
+		t.Fatalf("NewFileChunkStore (reopen): %v", err)
+	}
+	done, err = reopened.Completed("abc", 137)
+	if err != nil {
+		t.Fatalf("Completed (reopen): %v", err)
+	}
+	if len(done) != 2 {
+		t.Errorf("Completed(abc, 137) after reopen = %v, want 2 entries", done)
+	}
+}
+
+func TestVerifyResumeKeyContentLengthMismatch(t *testing.T) {
+	format := &Format{ContentLength: 1000}
+	key := &ResumeKey{ContentLength: 999}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/video", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	if err := verifyResumeKey(context.Background(), &Client{}, req, format, key); err == nil {
+		t.Error("verifyResumeKey: got nil error, want error for mismatched content-length")
+	}
+}
+
+func TestVerifyResumeKeyNoETagRequested(t *testing.T) {
+	format := &Format{ContentLength: 1000}
+	key := &ResumeKey{ContentLength: 1000}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/video", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	// With ContentLength matching and no ETag to check, verifyResumeKey must
+	// not attempt a network round trip.
+	if err := verifyResumeKey(context.Background(), &Client{}, req, format, key); err != nil {
+		t.Errorf("verifyResumeKey: %v, want nil", err)
+	}
+}
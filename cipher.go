@@ -0,0 +1,199 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var ErrCipherNotFound = errors.New("cipher not found")
+
+// playerCache caches a video's player JS body, keyed by its URL, so repeated
+// decipher/n-param calls for the same player version don't re-fetch it.
+type playerCache struct {
+	mu    sync.Mutex
+	byURL map[string]string
+}
+
+func (p *playerCache) get(jsURL string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	js, ok := p.byURL[jsURL]
+	return js, ok
+}
+
+func (p *playerCache) set(jsURL, js string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.byURL == nil {
+		p.byURL = map[string]string{}
+	}
+	p.byURL[jsURL] = js
+}
+
+var playerJSURLPattern = regexp.MustCompile(`"jsUrl":"([^"]+)"`)
+
+// getPlayerJS fetches (and caches) the player JS body used to decipher
+// signatures and de-throttle n-params for videoID.
+func (c *Client) getPlayerJS(ctx context.Context, videoID string) (string, error) {
+	watchBody, err := c.httpGetBodyBytes(ctx, "https://www.youtube.com/watch?v="+videoID)
+	if err != nil {
+		return "", fmt.Errorf("fetch watch page: %w", err)
+	}
+
+	match := playerJSURLPattern.FindSubmatch(watchBody)
+	if match == nil {
+		return "", errors.New("youtube: player JS URL not found")
+	}
+
+	jsURL := "https://www.youtube.com" + strings.ReplaceAll(string(match[1]), `\/`, "/")
+
+	if js, ok := c.playerCache.get(jsURL); ok {
+		return js, nil
+	}
+
+	jsBody, err := c.httpGetBodyBytes(ctx, jsURL)
+	if err != nil {
+		return "", fmt.Errorf("fetch player JS: %w", err)
+	}
+
+	js := string(jsBody)
+	c.playerCache.set(jsURL, js)
+
+	return js, nil
+}
+
+// decipherURL deciphers a format's signatureCipher (s/sp/url query params)
+// into a playable URL, running the extracted signature function through
+// c.jsRuntime().
+func (c *Client) decipherURL(ctx context.Context, videoID, cipher string) (string, error) {
+	values, err := url.ParseQuery(cipher)
+	if err != nil {
+		return "", fmt.Errorf("parse cipher: %w", err)
+	}
+
+	sig := values.Get("s")
+	rawURL := values.Get("url")
+	if sig == "" || rawURL == "" {
+		return "", ErrCipherNotFound
+	}
+
+	playerJS, err := c.getPlayerJS(ctx, videoID)
+	if err != nil {
+		return "", err
+	}
+
+	deciphered, err := c.jsRuntime().RunSigFunc(playerJS, sig)
+	if err != nil {
+		return "", fmt.Errorf("decipher signature: %w", err)
+	}
+
+	sp := values.Get("sp")
+	if sp == "" {
+		sp = "signature"
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse format url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set(sp, deciphered)
+	u.RawQuery = q.Encode()
+
+	return c.unThrottle(ctx, videoID, u.String())
+}
+
+// unThrottle de-throttles rawURL's "n" query parameter, running the
+// extracted n-param function through c.jsRuntime(). URLs without an "n"
+// parameter are returned unchanged.
+func (c *Client) unThrottle(ctx context.Context, videoID, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse format url: %w", err)
+	}
+
+	q := u.Query()
+	n := q.Get("n")
+	if n == "" {
+		return rawURL, nil
+	}
+
+	playerJS, err := c.getPlayerJS(ctx, videoID)
+	if err != nil {
+		return "", err
+	}
+
+	deThrottled, err := c.jsRuntime().RunNFunc(playerJS, n)
+	if err != nil {
+		return "", fmt.Errorf("run n-param function: %w", err)
+	}
+
+	q.Set("n", deThrottled)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// extractFunctionExpr returns the "function(...){...}" expression bound to
+// name in playerJS (matching both "name=function(" and "name:function("
+// bindings), found by balancing braces from the first "{" after "function(".
+func extractFunctionExpr(playerJS, name string) (string, error) {
+	idx := strings.Index(playerJS, name+"=function(")
+	marker := name + "="
+	if idx == -1 {
+		idx = strings.Index(playerJS, name+":function(")
+		marker = name + ":"
+	}
+	if idx == -1 {
+		return "", fmt.Errorf("youtube: function %q not found in player JS", name)
+	}
+
+	start := idx + len(marker)
+
+	return extractBalancedBraces(playerJS, start)
+}
+
+// extractObjectLiteral returns the "{...}" object literal bound to name in
+// playerJS (e.g. the helper object a signature cipher function calls into).
+func extractObjectLiteral(playerJS, name string) (string, error) {
+	idx := strings.Index(playerJS, name+"={")
+	if idx == -1 {
+		return "", fmt.Errorf("youtube: object %q not found in player JS", name)
+	}
+
+	return extractBalancedBraces(playerJS, idx+len(name+"="))
+}
+
+// extractBalancedBraces returns playerJS[start:end], where the substring
+// starting at the first "{" at or after start is balanced against its
+// matching "}".
+func extractBalancedBraces(playerJS string, start int) (string, error) {
+	braceIdx := strings.IndexByte(playerJS[start:], '{')
+	if braceIdx == -1 {
+		return "", errors.New("youtube: no opening brace found")
+	}
+	braceIdx += start
+
+	depth := 0
+	for i := braceIdx; i < len(playerJS); i++ {
+		switch playerJS[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return playerJS[start : i+1], nil
+			}
+		}
+	}
+
+	return "", errors.New("youtube: unbalanced braces")
+}
@@ -13,8 +13,11 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -44,6 +47,52 @@ type Client struct {
 	// ChunkSize to use when downloading videos in chunks. Default is Size10Mb.
 	ChunkSize int64
 
+	// IPPool is a list of local IP addresses to bind outgoing chunk download
+	// connections to via net.Dialer.LocalAddr, cycled round-robin across the
+	// goroutines spawned by downloadChunked.
+	IPPool []string
+
+	// ProxyPool is a list of proxy URLs cycled round-robin across chunk
+	// download goroutines, in addition to or instead of IPPool. A proxy is
+	// put on cooldown after it returns a throttle response.
+	ProxyPool []*url.URL
+
+	endpoints     *endpointPool
+	endpointsOnce sync.Once
+
+	// PoToken is a pre-generated PoToken (Proof of Origin token) to send
+	// alongside player and chunked download requests. If empty and
+	// PoTokenProvider is set, a token is generated lazily on first use.
+	PoToken string
+
+	// PoTokenProvider generates a PoToken when YouTube starts requiring one
+	// for the current client (WEB/ANDROID are affected, IOS currently is not).
+	// Left nil, the client falls back to retrying the player request with
+	// IOSClient instead of minting a token.
+	PoTokenProvider PoTokenProvider
+
+	// contentPoToken is the content-bound PoToken (potc) returned alongside
+	// PoToken by PoTokenProvider.Generate, used on chunked download URLs.
+	contentPoToken string
+
+	// JSRuntime evaluates the signature-cipher and n-parameter deciphering
+	// functions extracted from the player JS. Defaults to DefaultJSRuntime
+	// (GojaRuntime) when nil.
+	JSRuntime JSRuntime
+
+	// ProgressCallback, if set, is invoked as download progress is made by
+	// downloadOnce and downloadChunk. Use DownloadOptions.ProgressCallback
+	// to override it for a single GetStreamContextWithOptions call.
+	ProgressCallback ProgressFunc
+
+	// MaxBytesPerSecond caps the aggregate download bandwidth across all
+	// chunk goroutines spawned by downloadChunked, and downloadOnce. 0
+	// means unlimited.
+	MaxBytesPerSecond int64
+
+	bwLimiter     *rate.Limiter
+	bwLimiterOnce sync.Once
+
 	// playerCache caches the JavaScript code of a player response
 	playerCache playerCache
 
@@ -57,12 +106,38 @@ type Client struct {
 	}
 }
 
+// PoTokenProvider generates a PoToken (Proof of Origin token) for a video.
+// YouTube increasingly requires this token, produced by a BotGuard/JS-based
+// attestation flow, before it will serve full-quality streaming URLs to the
+// WEB and ANDROID clients. Callers that need this should wire in their own
+// implementation, e.g. one that drives a headless browser or a vendored
+// BotGuard VM; the library does not ship one itself.
+type PoTokenProvider interface {
+	// Generate returns the session poToken and, if available, the
+	// content-bound poToken (potc) for videoID. visitorData is the
+	// visitor ID of the innertube context the request will be sent with.
+	Generate(ctx context.Context, videoID, visitorData string) (poToken, contentPoToken string, err error)
+}
+
 func (c *Client) assureClient() {
 	if c.client == nil {
 		c.client = &DefaultClient
 	}
 }
 
+// endpointPool lazily builds the IPPool/ProxyPool round-robin pool used by
+// downloadChunked. Returns nil if neither is configured.
+func (c *Client) endpointPool() *endpointPool {
+	c.endpointsOnce.Do(func() {
+		if len(c.IPPool) == 0 && len(c.ProxyPool) == 0 {
+			return
+		}
+		c.endpoints = newEndpointPool(c.IPPool, c.ProxyPool)
+	})
+
+	return c.endpoints
+}
+
 // GetVideo fetches video metadata
 func (c *Client) GetVideo(url string) (*Video, error) {
 	return c.GetVideoContext(context.Background(), url)
@@ -86,6 +161,22 @@ func (c *Client) videoFromID(ctx context.Context, id string) (*Video, error) {
 		return nil, err
 	}
 
+	// YouTube is rolling out SABR/PoToken enforcement on WEB and ANDROID: a
+	// missing signatureCipher/url or an explicit PoToken complaint means the
+	// player response is unusable. IOSClient isn't enforced yet, so fall back
+	// to it rather than failing the whole request.
+	if requiresPoToken(*c.client, body) && c.client.name != IOSClient.name {
+		fallback := *c.client
+		c.client = &IOSClient
+
+		iosBody, iosErr := c.videoDataByInnertube(ctx, id)
+		if iosErr == nil && !requiresPoToken(*c.client, iosBody) {
+			body = iosBody
+		} else {
+			c.client = &fallback
+		}
+	}
+
 	v := Video{
 		ID: id,
 	}
@@ -150,6 +241,7 @@ type playbackContext struct {
 type contentPlaybackContext struct {
 	// SignatureTimestamp string `json:"signatureTimestamp"`
 	HTML5Preference string `json:"html5Preference"`
+	PoToken         string `json:"poToken,omitempty"`
 }
 
 type inntertubeContext struct {
@@ -216,6 +308,17 @@ var (
 )
 
 func (c *Client) videoDataByInnertube(ctx context.Context, id string) ([]byte, error) {
+	cpc := contentPlaybackContext{
+		// SignatureTimestamp: sts,
+		HTML5Preference: "HTML5_PREF_WANTS",
+	}
+
+	if poToken, err := c.getPoToken(ctx, id); err != nil {
+		slog.Debug("PoToken generation failed, continuing without one", "error", err)
+	} else if poToken != "" {
+		cpc.PoToken = poToken
+	}
+
 	data := innertubeRequest{
 		VideoID:        id,
 		Context:        prepareInnertubeContext(*c.client),
@@ -223,16 +326,101 @@ func (c *Client) videoDataByInnertube(ctx context.Context, id string) ([]byte, e
 		RacyCheckOk:    true,
 		// Params:                   playerParams,
 		PlaybackContext: &playbackContext{
-			ContentPlaybackContext: contentPlaybackContext{
-				// SignatureTimestamp: sts,
-				HTML5Preference: "HTML5_PREF_WANTS",
-			},
+			ContentPlaybackContext: cpc,
 		},
 	}
 
 	return c.httpPostBodyBytes(ctx, "https://www.youtube.com/youtubei/v1/player?key="+c.client.key, data)
 }
 
+// getPoToken returns the PoToken to attach to the player request, generating
+// one via PoTokenProvider if needed. The content-bound token (potc), if any,
+// is cached on the client for use on chunked download URLs.
+func (c *Client) getPoToken(ctx context.Context, videoID string) (string, error) {
+	if c.PoToken != "" {
+		return c.PoToken, nil
+	}
+
+	if c.PoTokenProvider == nil {
+		return "", nil
+	}
+
+	visitorData, err := c.getVisitorId()
+	if err != nil {
+		return "", err
+	}
+
+	poToken, contentPoToken, err := c.PoTokenProvider.Generate(ctx, videoID, visitorData)
+	if err != nil {
+		return "", err
+	}
+
+	c.PoToken = poToken
+	c.contentPoToken = contentPoToken
+
+	return poToken, nil
+}
+
+// requiresPoToken reports whether body looks like a player response that
+// YouTube refused to fully serve because of missing PoToken enforcement.
+// This only applies to the WEB and ANDROID clients; IOS is not enforced yet.
+func requiresPoToken(client clientInfo, body []byte) bool {
+	if client.name != WebClient.name && client.name != AndroidClient.name {
+		return false
+	}
+
+	if bytes.Contains(body, []byte("PoToken")) && bytes.Contains(body, []byte("requires")) {
+		return true
+	}
+
+	streamingData, ok := extractStreamingDataJSON(body)
+	if !ok {
+		// No streamingData at all is just as unplayable as an empty one.
+		return true
+	}
+
+	// No formats carry a playable URL at all: either signatureCipher (which
+	// still needs deciphering) or a plain url are missing from every format.
+	return !bytes.Contains(streamingData, []byte(`"signatureCipher"`)) && !bytes.Contains(streamingData, []byte(`"url":"`))
+}
+
+// extractStreamingDataJSON returns the raw "streamingData":{...} object out
+// of a player response body, balancing braces the same way cipher.go's
+// extractBalancedBraces does. requiresPoToken scopes its "no playable url"
+// check to this rather than the whole body, since unrelated top-level
+// fields (captions, thumbnails, ...) also contain "url" keys and would
+// otherwise mask a response that genuinely has no playable formats.
+func extractStreamingDataJSON(body []byte) ([]byte, bool) {
+	const marker = `"streamingData":`
+
+	idx := bytes.Index(body, []byte(marker))
+	if idx == -1 {
+		return nil, false
+	}
+	start := idx + len(marker)
+
+	braceIdx := bytes.IndexByte(body[start:], '{')
+	if braceIdx == -1 {
+		return nil, false
+	}
+	braceIdx += start
+
+	depth := 0
+	for i := braceIdx; i < len(body); i++ {
+		switch body[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return body[braceIdx : i+1], true
+			}
+		}
+	}
+
+	return nil, false
+}
+
 func (c *Client) transcriptDataByInnertube(ctx context.Context, id string, lang string) ([]byte, error) {
 	data := innertubeRequest{
 		Context: prepareInnertubeContext(*c.client),
@@ -364,6 +552,13 @@ func (c *Client) GetStream(video *Video, format *Format, senderChan chan<- audio
 
 // GetStreamContext returns the stream and the total size for a specific format with a context.
 func (c *Client) GetStreamContext(ctx context.Context, video *Video, format *Format, senderChan chan<- audioData) (int64, error) {
+	return c.GetStreamContextWithOptions(ctx, video, format, senderChan, nil)
+}
+
+// GetStreamContextWithOptions is GetStreamContext with resume and chunk-retry
+// behaviour controlled by opts. Passing nil opts behaves exactly like
+// GetStreamContext.
+func (c *Client) GetStreamContextWithOptions(ctx context.Context, video *Video, format *Format, senderChan chan<- audioData, opts *DownloadOptions) (int64, error) {
 	url, err := c.GetStreamURL(video, format)
 	if err != nil {
 		return 0, err
@@ -374,30 +569,36 @@ func (c *Client) GetStreamContext(ctx context.Context, video *Video, format *For
 		return 0, err
 	}
 
-
 	contentLength := format.ContentLength
 
 	if contentLength == 0 {
 		// some videos don't have length information
-		contentLength = c.downloadOnce(req,  senderChan)
+		contentLength = c.downloadOnce(req, senderChan, opts)
 	} else {
 		// we have length information, let's download by chunks!
-		c.downloadChunked(ctx, req, format, senderChan)
+		if err := c.downloadChunked(ctx, req, format, senderChan, opts); err != nil {
+			return 0, err
+		}
 	}
 
 	return contentLength, nil
 }
 
-func (c *Client) downloadOnce(req *http.Request,  senderChan chan<- audioData) int64 {
+func (c *Client) downloadOnce(req *http.Request, senderChan chan<- audioData, opts *DownloadOptions) int64 {
 	resp, err := c.httpDo(req)
 	if err != nil {
 
 		return 0
 	}
 
+	contentLength := resp.Header.Get("Content-Length")
+	length, _ := strconv.ParseInt(contentLength, 10, 64)
+
+	body := wrapBody(req.Context(), resp.Body, c.bandwidthLimiter(), c.progressCallback(opts), length, 1, 1)
+
 	go func() {
 		defer resp.Body.Close()
-		data, err := io.ReadAll(resp.Body)
+		data, err := io.ReadAll(body)
 		if err != nil {
 			slog.Error("Failed to read response body", "error", err)
 			return
@@ -409,9 +610,6 @@ func (c *Client) downloadOnce(req *http.Request,  senderChan chan<- audioData) i
 		}
 	}()
 
-	contentLength := resp.Header.Get("Content-Length")
-	length, _ := strconv.ParseInt(contentLength, 10, 64)
-
 	return length
 }
 
@@ -437,9 +635,50 @@ func (c *Client) getMaxRoutines(limit int) int {
 	return routines
 }
 
-func (c *Client) downloadChunked(ctx context.Context, req *http.Request, format *Format, senderChan chan<- audioData) {
+func (c *Client) downloadChunked(ctx context.Context, req *http.Request, format *Format, senderChan chan<- audioData, opts *DownloadOptions) error {
 	chunks := getChunks(format.ContentLength, c.getChunkSize())
+
+	var store ChunkStore
+	completed := map[int]bool{}
+	chunkRetries := 0
+
+	if opts != nil {
+		chunkRetries = opts.ChunkRetries
+
+		if opts.ResumeKey != nil {
+			store = opts.ChunkStore
+			if store == nil {
+				var err error
+				store, err = NewFileChunkStore(opts.ResumeKey.OutputPath)
+				if err != nil {
+					return fmt.Errorf("open chunk store: %w", err)
+				}
+			}
+
+			if err := verifyResumeKey(ctx, c, req, format, opts.ResumeKey); err != nil {
+				// The sidecar state was recorded against a different
+				// encode of this format (YouTube reissued the format URL,
+				// or the remote file simply changed): trust nothing it
+				// remembers and
+				// re-download every chunk rather than stitch mismatched
+				// data together.
+				completed = map[int]bool{}
+			} else {
+				done, err := store.Completed(opts.ResumeKey.VideoID, opts.ResumeKey.Itag)
+				if err != nil {
+					return fmt.Errorf("read chunk store state: %w", err)
+				}
+				for _, idx := range done {
+					completed[idx] = true
+				}
+			}
+		}
+	}
+
 	maxRoutines := c.getMaxRoutines(len(chunks))
+	pool := c.endpointPool()
+	limiter := c.bandwidthLimiter()
+	progress := c.progressCallback(opts)
 
 	cancelCtx, cancel := context.WithCancel(ctx)
 	abort := func(err error) {
@@ -459,7 +698,31 @@ func (c *Client) downloadChunked(ctx context.Context, req *http.Request, format
 				}
 
 				chunk := &chunks[chunkIndex]
-				err := c.downloadChunk(req.Clone(cancelCtx), chunk, uint64(totalChunks))
+
+				if completed[chunkIndex] {
+					// resumed from a previous run; the caller already has
+					// these bytes on disk
+					close(chunk.data)
+					continue
+				}
+
+				ep, err := pool.Acquire(cancelCtx)
+				if err != nil {
+					close(chunk.data)
+					abort(err)
+					return
+				}
+
+				chunkCtx := withEndpoint(cancelCtx, ep)
+				err = c.downloadChunkWithRetry(req.Clone(chunkCtx), chunk, uint64(totalChunks), chunkRetries, format.ContentLength, limiter, progress)
+				pool.Release(ep)
+
+				if err == nil && store != nil {
+					if markErr := store.MarkComplete(opts.ResumeKey.VideoID, opts.ResumeKey.Itag, chunkIndex); markErr != nil {
+						slog.Warn("failed to record chunk completion", "error", markErr)
+					}
+				}
+
 				close(chunk.data)
 
 				if err != nil {
@@ -476,14 +739,50 @@ func (c *Client) downloadChunked(ctx context.Context, req *http.Request, format
 			select {
 			case <-cancelCtx.Done():
 				abort(context.Canceled)
+				// signal abandonment: a consumer counting chunks against
+				// the total it was promised would otherwise block forever
+				// waiting for data that's never coming.
+				close(senderChan)
 				return
-			case data := <-chunks[i].data:
+			case data, ok := <-chunks[i].data:
+				if !ok {
+					if completed[i] {
+						// chunk was skipped on resume, nothing to forward
+						continue
+					}
+					// chunk failed and the download was aborted: stop
+					// rather than forward later chunks past the gap
+					close(senderChan)
+					return
+				}
 				senderChan <- data
 			}
 		}
 
 		// everything succeeded
 	}()
+
+	return nil
+}
+
+// downloadChunkWithRetry calls downloadChunk, retrying a failed chunk up to
+// retries times with exponential backoff instead of failing the whole
+// stream on the first error.
+func (c *Client) downloadChunkWithRetry(req *http.Request, chunk *chunk, totalChunks uint64, retries int, totalBytes int64, limiter *rate.Limiter, progress ProgressFunc) error {
+	var err error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		if err = c.downloadChunk(req, chunk, totalChunks, totalBytes, limiter, progress); err == nil {
+			return nil
+		}
+
+		if attempt < retries {
+			time.Sleep(time.Duration(1<<uint(attempt)) * 250 * time.Millisecond)
+			req = req.Clone(req.Context())
+		}
+	}
+
+	return err
 }
 
 // GetStreamURL returns the url for a specific format
@@ -522,13 +821,35 @@ func (c *Client) GetStreamURLContext(ctx context.Context, video *Video, format *
 	return uri, err
 }
 
-// httpDo sends an HTTP request and returns an HTTP response.
+// maxThrottleRetries bounds how many times httpDo rotates to a fresh
+// endpoint after a 429/403 before giving up and returning the error.
+const maxThrottleRetries = 3
+
+func isThrottleStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusForbidden
+}
+
+// httpDo sends an HTTP request and returns an HTTP response. If an
+// IPPool/ProxyPool is configured and the request gets throttled, it cools
+// down the request's Endpoint, if it carries one (set via withEndpoint, as
+// downloadChunked does), acquires another one from the pool, and retries
+// through it with exponential backoff.
 func (c *Client) httpDo(req *http.Request) (*http.Response, error) {
+	return c.httpDoAttempt(req, 0)
+}
+
+func (c *Client) httpDoAttempt(req *http.Request, attempt int) (*http.Response, error) {
 	client := c.HTTPClient
 	if client == nil {
 		client = http.DefaultClient
 	}
 
+	if ep := endpointFromContext(req.Context()); ep != nil {
+		clientCopy := *client
+		clientCopy.Transport = ep.transport()
+		client = &clientCopy
+	}
+
 	req.Header.Set("User-Agent", c.client.userAgent)
 	req.Header.Set("Origin", "https://youtube.com")
 	req.Header.Set("Sec-Fetch-Mode", "navigate")
@@ -548,6 +869,23 @@ func (c *Client) httpDo(req *http.Request) (*http.Response, error) {
 
 	log := slog.With("method", req.Method, "url", req.URL)
 
+	if err == nil && isThrottleStatus(res.StatusCode) {
+		res.Body.Close()
+
+		if pool := c.endpointPool(); pool != nil && attempt < maxThrottleRetries {
+			pool.CoolDown(endpointFromContext(req.Context()))
+
+			backoff := time.Duration(1<<uint(attempt)) * 500 * time.Millisecond
+			log.Debug("throttled, rotating endpoint", "status", res.StatusCode, "backoff", backoff)
+			time.Sleep(backoff)
+
+			nextEp, acquireErr := pool.Acquire(req.Context())
+			if acquireErr == nil {
+				return c.httpDoAttempt(req.Clone(withEndpoint(req.Context(), nextEp)), attempt+1)
+			}
+		}
+	}
+
 	if err == nil && res.StatusCode != http.StatusOK {
 		err = ErrUnexpectedStatusCode(res.StatusCode)
 		res.Body.Close()
@@ -695,9 +1033,18 @@ func (c *Client) httpPostBodyBytes(ctx context.Context, url string, body interfa
 // downloadChunk writes the response data into the data channel of the chunk.
 // Downloading in multiple chunks is much faster:
 // https://github.com/kkdai/youtube/pull/190
-func (c *Client) downloadChunk(req *http.Request, chunk *chunk, totalChunks uint64) error {
+func (c *Client) downloadChunk(req *http.Request, chunk *chunk, totalChunks uint64, totalBytes int64, limiter *rate.Limiter, progress ProgressFunc) error {
 	q := req.URL.Query()
 	q.Set("range", fmt.Sprintf("%d-%d", chunk.start, chunk.end))
+
+	if c.PoToken != "" {
+		q.Set("pot", c.PoToken)
+	}
+
+	if c.contentPoToken != "" {
+		q.Set("potc", c.contentPoToken)
+	}
+
 	req.URL.RawQuery = q.Encode()
 
 	resp, err := c.httpDo(req)
@@ -710,8 +1057,13 @@ func (c *Client) downloadChunk(req *http.Request, chunk *chunk, totalChunks uint
 		return ErrUnexpectedStatusCode(resp.StatusCode)
 	}
 
+	var body io.Reader = resp.Body
+	if limiter != nil {
+		body = &rateLimitedReader{ctx: req.Context(), r: body, limiter: limiter}
+	}
+
 	expected := int(chunk.end-chunk.start) + 1
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(body)
 	n := len(data)
 
 	if err != nil {
@@ -722,6 +1074,10 @@ func (c *Client) downloadChunk(req *http.Request, chunk *chunk, totalChunks uint
 		return fmt.Errorf("chunk at offset %d has invalid size: expected=%d actual=%d", chunk.start, expected, n)
 	}
 
+	if progress != nil {
+		progress(int64(n), totalBytes, int(chunk.num), int(totalChunks))
+	}
+
 	chunk.data <- audioData{
 		data:        data,
 		chunkNum:   chunk.num,
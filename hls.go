@@ -0,0 +1,230 @@
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotLive = errors.New("video has no HLS manifest")
+
+// ErrHLSVariantNotFound is returned when a master playlist has no variant
+// matching the requested format's itag.
+var ErrHLSVariantNotFound = errors.New("youtube: no matching variant in HLS master playlist")
+
+// HLSSegment is a single media segment from an HLS playlist.
+type HLSSegment struct {
+	URI string
+
+	// Discontinuity is true if the segment was preceded by
+	// #EXT-X-DISCONTINUITY, meaning the decoder should reset its state
+	// before appending it.
+	Discontinuity bool
+}
+
+// HLSPlaylist is a parsed HLS media playlist for a live or premiere video.
+type HLSPlaylist struct {
+	// TargetDuration is the #EXT-X-TARGETDURATION value, used as the poll
+	// interval while the stream is live.
+	TargetDuration time.Duration
+
+	Segments []HLSSegment
+
+	// Ended is true once #EXT-X-ENDLIST has been seen.
+	Ended bool
+}
+
+// GetHLSManifest fetches and parses the HLS media playlist for format from a
+// live or premiere video's HLSManifestURL. HLSManifestURL itself is usually a
+// master playlist listing one variant media playlist per itag, in which case
+// the variant matching format is fetched and parsed; a HLSManifestURL that is
+// already a flat media playlist is parsed as-is.
+func (c *Client) GetHLSManifest(ctx context.Context, video *Video, format *Format) (*HLSPlaylist, error) {
+	if video.HLSManifestURL == "" {
+		return nil, ErrNotLive
+	}
+
+	body, err := c.httpGetBodyBytes(ctx, video.HLSManifestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if variants, ok := parseHLSMasterPlaylist(body); ok {
+		variantURL, err := selectHLSVariant(variants, format)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err = c.httpGetBodyBytes(ctx, variantURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parseHLSPlaylist(body)
+}
+
+// hlsVariant is one #EXT-X-STREAM-INF entry in an HLS master playlist: a
+// per-itag media playlist URI.
+type hlsVariant struct {
+	ItagNo int
+	URI    string
+}
+
+// hlsVariantItagPattern pulls the itag out of a variant media playlist URI,
+// which YouTube embeds as an "/itag/<n>/" path segment.
+var hlsVariantItagPattern = regexp.MustCompile(`/itag/(\d+)/`)
+
+// parseHLSMasterPlaylist parses body as an HLS master playlist, returning its
+// variants and true if it is one (identified by any #EXT-X-STREAM-INF line);
+// ok is false for a flat media playlist, which the caller should parse with
+// parseHLSPlaylist directly instead.
+func parseHLSMasterPlaylist(body []byte) ([]hlsVariant, bool) {
+	if !bytes.Contains(body, []byte("#EXT-X-STREAM-INF")) {
+		return nil, false
+	}
+
+	var variants []hlsVariant
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	var pendingVariant bool
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			pendingVariant = true
+		case strings.HasPrefix(line, "#"):
+			continue
+		case pendingVariant:
+			variant := hlsVariant{URI: line}
+			if m := hlsVariantItagPattern.FindStringSubmatch(line); m != nil {
+				variant.ItagNo, _ = strconv.Atoi(m[1])
+			}
+			variants = append(variants, variant)
+			pendingVariant = false
+		}
+	}
+
+	return variants, true
+}
+
+// selectHLSVariant returns the variant whose itag matches format, falling
+// back to the first variant if format is nil or none matches its itag
+// exactly (some master playlists list variants YouTube didn't also expose as
+// a regular adaptive format, so an exact itag match isn't guaranteed).
+func selectHLSVariant(variants []hlsVariant, format *Format) (string, error) {
+	if len(variants) == 0 {
+		return "", ErrHLSVariantNotFound
+	}
+
+	if format != nil {
+		for _, v := range variants {
+			if v.ItagNo == format.ItagNo {
+				return v.URI, nil
+			}
+		}
+	}
+
+	return variants[0].URI, nil
+}
+
+func parseHLSPlaylist(body []byte) (*HLSPlaylist, error) {
+	playlist := &HLSPlaylist{}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+
+	var pendingDiscontinuity bool
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"))
+			if err != nil {
+				return nil, fmt.Errorf("parse target duration: %w", err)
+			}
+			playlist.TargetDuration = time.Duration(seconds) * time.Second
+		case line == "#EXT-X-DISCONTINUITY":
+			pendingDiscontinuity = true
+		case line == "#EXT-X-ENDLIST":
+			playlist.Ended = true
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			playlist.Segments = append(playlist.Segments, HLSSegment{
+				URI:           line,
+				Discontinuity: pendingDiscontinuity,
+			})
+			pendingDiscontinuity = false
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return playlist, nil
+}
+
+// StreamLive polls the HLS media playlist for a live broadcast and dispatches
+// newly-appended segments through sender as they show up, with a
+// monotonically-increasing chunkNum and totalChunks set to 0 to signal an
+// unbounded stream. It returns once #EXT-X-ENDLIST appears or ctx is done.
+func (c *Client) StreamLive(ctx context.Context, video *Video, format *Format, sender chan<- audioData) error {
+	seen := make(map[string]bool)
+	var chunkNum uint64
+
+	for {
+		playlist, err := c.GetHLSManifest(ctx, video, format)
+		if err != nil {
+			return err
+		}
+
+		for _, seg := range playlist.Segments {
+			if seen[seg.URI] {
+				continue
+			}
+			seen[seg.URI] = true
+
+			data, err := c.httpGetBodyBytes(ctx, seg.URI)
+			if err != nil {
+				return fmt.Errorf("fetch segment %s: %w", seg.URI, err)
+			}
+
+			chunkNum++
+			select {
+			case sender <- audioData{data: data, chunkNum: chunkNum, totalChunks: 0}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if playlist.Ended {
+			return nil
+		}
+
+		interval := playlist.TargetDuration
+		if interval <= 0 {
+			interval = time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
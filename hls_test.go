@@ -0,0 +1,140 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHLSPlaylist(t *testing.T) {
+	body := []byte(`#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.006,
+https://example.com/seg-0.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:6.006,
+https://example.com/seg-1.ts
+#EXTINF:6.006,
+https://example.com/seg-2.ts
+#EXT-X-ENDLIST
+`)
+
+	playlist, err := parseHLSPlaylist(body)
+	if err != nil {
+		t.Fatalf("parseHLSPlaylist: %v", err)
+	}
+
+	if playlist.TargetDuration != 6*time.Second {
+		t.Errorf("TargetDuration = %v, want 6s", playlist.TargetDuration)
+	}
+
+	if !playlist.Ended {
+		t.Error("Ended = false, want true")
+	}
+
+	want := []HLSSegment{
+		{URI: "https://example.com/seg-0.ts", Discontinuity: false},
+		{URI: "https://example.com/seg-1.ts", Discontinuity: true},
+		{URI: "https://example.com/seg-2.ts", Discontinuity: false},
+	}
+
+	if len(playlist.Segments) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(playlist.Segments), len(want))
+	}
+
+	for i, seg := range playlist.Segments {
+		if seg != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, seg, want[i])
+		}
+	}
+}
+
+func TestParseHLSPlaylistLive(t *testing.T) {
+	body := []byte(`#EXTM3U
+#EXT-X-TARGETDURATION:4
+https://example.com/seg-0.ts
+`)
+
+	playlist, err := parseHLSPlaylist(body)
+	if err != nil {
+		t.Fatalf("parseHLSPlaylist: %v", err)
+	}
+
+	if playlist.Ended {
+		t.Error("Ended = true, want false for a playlist with no #EXT-X-ENDLIST")
+	}
+}
+
+func TestParseHLSPlaylistInvalidTargetDuration(t *testing.T) {
+	body := []byte("#EXT-X-TARGETDURATION:notanumber\n")
+
+	if _, err := parseHLSPlaylist(body); err == nil {
+		t.Error("parseHLSPlaylist: got nil error, want error for invalid target duration")
+	}
+}
+
+func TestParseHLSMasterPlaylist(t *testing.T) {
+	body := []byte(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,CODECS="avc1.4d401f"
+https://example.com/manifest/itag/137/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=640000,CODECS="avc1.4d401e"
+https://example.com/manifest/itag/136/index.m3u8
+`)
+
+	variants, ok := parseHLSMasterPlaylist(body)
+	if !ok {
+		t.Fatal("parseHLSMasterPlaylist: ok = false, want true for a master playlist")
+	}
+
+	want := []hlsVariant{
+		{ItagNo: 137, URI: "https://example.com/manifest/itag/137/index.m3u8"},
+		{ItagNo: 136, URI: "https://example.com/manifest/itag/136/index.m3u8"},
+	}
+
+	if len(variants) != len(want) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(want))
+	}
+
+	for i, v := range variants {
+		if v != want[i] {
+			t.Errorf("variant %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+}
+
+func TestParseHLSMasterPlaylistRejectsFlatPlaylist(t *testing.T) {
+	body := []byte(`#EXTM3U
+#EXT-X-TARGETDURATION:6
+https://example.com/seg-0.ts
+`)
+
+	if _, ok := parseHLSMasterPlaylist(body); ok {
+		t.Error("parseHLSMasterPlaylist: ok = true, want false for a flat media playlist")
+	}
+}
+
+func TestSelectHLSVariant(t *testing.T) {
+	variants := []hlsVariant{
+		{ItagNo: 137, URI: "https://example.com/137.m3u8"},
+		{ItagNo: 136, URI: "https://example.com/136.m3u8"},
+	}
+
+	uri, err := selectHLSVariant(variants, &Format{ItagNo: 136})
+	if err != nil {
+		t.Fatalf("selectHLSVariant: %v", err)
+	}
+	if uri != "https://example.com/136.m3u8" {
+		t.Errorf("selectHLSVariant matched itag 136 = %q, want https://example.com/136.m3u8", uri)
+	}
+
+	uri, err = selectHLSVariant(variants, &Format{ItagNo: 999})
+	if err != nil {
+		t.Fatalf("selectHLSVariant: %v", err)
+	}
+	if uri != variants[0].URI {
+		t.Errorf("selectHLSVariant with no itag match = %q, want fallback to first variant %q", uri, variants[0].URI)
+	}
+
+	if _, err := selectHLSVariant(nil, &Format{ItagNo: 137}); err != ErrHLSVariantNotFound {
+		t.Errorf("selectHLSVariant(nil, ...) error = %v, want ErrHLSVariantNotFound", err)
+	}
+}
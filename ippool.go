@@ -0,0 +1,124 @@
+package youtube
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// throttleCooldown is how long an endpoint is excluded from Acquire after it
+// gets hit with a throttle response.
+const throttleCooldown = 30 * time.Second
+
+// Endpoint is a single source IP or proxy that downloadChunked can route a
+// chunk request through.
+type Endpoint struct {
+	// LocalAddr, if set, is bound via net.Dialer.LocalAddr.
+	LocalAddr net.Addr
+
+	// Proxy, if set, is used as the transport's proxy URL.
+	Proxy *url.URL
+
+	coolUntil time.Time
+}
+
+func (e *Endpoint) transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		LocalAddr: e.LocalAddr,
+	}
+
+	t := &http.Transport{DialContext: dialer.DialContext}
+	if e.Proxy != nil {
+		t.Proxy = http.ProxyURL(e.Proxy)
+	}
+
+	return t
+}
+
+// endpointPool round-robins a fixed set of Endpoints across concurrent chunk
+// downloads, putting an endpoint on cooldown once it gets throttled.
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*Endpoint
+	next      int
+}
+
+// newEndpointPool builds a pool from local bind IPs and/or proxy URLs. Either
+// may be empty; a pool with no endpoints is valid and Acquire returns nil.
+func newEndpointPool(ips []string, proxies []*url.URL) *endpointPool {
+	pool := &endpointPool{}
+
+	for _, ip := range ips {
+		pool.endpoints = append(pool.endpoints, &Endpoint{LocalAddr: &net.TCPAddr{IP: net.ParseIP(ip)}})
+	}
+
+	for _, p := range proxies {
+		pool.endpoints = append(pool.endpoints, &Endpoint{Proxy: p})
+	}
+
+	return pool
+}
+
+// Acquire returns the next endpoint that isn't cooling off, round-robin. It
+// blocks, polling, until one becomes available or ctx is done.
+func (p *endpointPool) Acquire(ctx context.Context) (*Endpoint, error) {
+	if p == nil || len(p.endpoints) == 0 {
+		return nil, nil
+	}
+
+	for {
+		p.mu.Lock()
+		now := time.Now()
+		for i := 0; i < len(p.endpoints); i++ {
+			idx := (p.next + i) % len(p.endpoints)
+			ep := p.endpoints[idx]
+			if now.After(ep.coolUntil) {
+				p.next = idx + 1
+				p.mu.Unlock()
+				return ep, nil
+			}
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Release returns ep to the pool. Endpoints aren't exclusively owned between
+// Acquire and Release, so this only exists to make the acquire/release
+// pairing explicit at call sites.
+func (p *endpointPool) Release(ep *Endpoint) {}
+
+// CoolDown marks ep as throttled, excluding it from Acquire for a while.
+func (p *endpointPool) CoolDown(ep *Endpoint) {
+	if p == nil || ep == nil {
+		return
+	}
+
+	p.mu.Lock()
+	ep.coolUntil = time.Now().Add(throttleCooldown)
+	p.mu.Unlock()
+}
+
+type endpointCtxKey struct{}
+
+func withEndpoint(ctx context.Context, ep *Endpoint) context.Context {
+	if ep == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, endpointCtxKey{}, ep)
+}
+
+func endpointFromContext(ctx context.Context) *Endpoint {
+	ep, _ := ctx.Value(endpointCtxKey{}).(*Endpoint)
+	return ep
+}
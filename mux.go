@@ -0,0 +1,161 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// MuxOptions configures MuxStream.
+type MuxOptions struct {
+	// Container selects the output container: "mkv" (default), "mp4" or
+	// "webm".
+	Container string
+
+	// ReencodeVideo/ReencodeAudio, if set, are passed to ffmpeg as -c:v/-c:a
+	// instead of "copy" — useful when muxing codecs the chosen container
+	// can't carry as-is (e.g. Opus into mp4).
+	ReencodeVideo string
+	ReencodeAudio string
+
+	// FFmpegPath overrides the ffmpeg binary used to mux. Defaults to
+	// "ffmpeg" resolved via PATH.
+	FFmpegPath string
+}
+
+func (o MuxOptions) container() string {
+	if o.Container != "" {
+		return o.Container
+	}
+
+	return "mkv"
+}
+
+func (o MuxOptions) ffmpegPath() string {
+	if o.FFmpegPath != "" {
+		return o.FFmpegPath
+	}
+
+	return "ffmpeg"
+}
+
+var muxContainerFormats = map[string]string{
+	"mkv":  "matroska",
+	"mp4":  "mp4",
+	"webm": "webm",
+}
+
+// MuxStream downloads a video-only format and an audio-only format
+// concurrently, using the existing chunked download pipeline, and pipes both
+// into ffmpeg to produce a single muxed stream written to out. This is how
+// 1080p+ video (which YouTube only serves as an adaptive, video-only format)
+// gets combined with a separate audio track, the way a DASH-aware player
+// would.
+func (c *Client) MuxStream(ctx context.Context, video *Video, videoFormat, audioFormat *Format, out io.Writer, opts MuxOptions) error {
+	muxFormat, ok := muxContainerFormats[opts.container()]
+	if !ok {
+		return fmt.Errorf("youtube: unsupported mux container %q", opts.container())
+	}
+
+	videoRead, videoWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("open video pipe: %w", err)
+	}
+	defer videoRead.Close()
+
+	audioRead, audioWrite, err := os.Pipe()
+	if err != nil {
+		videoWrite.Close()
+		return fmt.Errorf("open audio pipe: %w", err)
+	}
+	defer audioRead.Close()
+
+	args := []string{
+		"-y",
+		"-i", "pipe:3",
+		"-i", "pipe:4",
+		"-c:v", firstNonEmpty(opts.ReencodeVideo, "copy"),
+		"-c:a", firstNonEmpty(opts.ReencodeAudio, "copy"),
+		"-f", muxFormat,
+		"pipe:1",
+	}
+
+	cmd := exec.CommandContext(ctx, opts.ffmpegPath(), args...)
+	cmd.ExtraFiles = []*os.File{videoRead, audioRead}
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		videoWrite.Close()
+		audioWrite.Close()
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+
+	// ffmpeg inherited its own copies of the read ends across fork/exec.
+	videoRead.Close()
+	audioRead.Close()
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- c.downloadFormatTo(ctx, video, videoFormat, videoWrite) }()
+	go func() { errCh <- c.downloadFormatTo(ctx, video, audioFormat, audioWrite) }()
+
+	var downloadErr error
+	for i := 0; i < 2; i++ {
+		if err := <-errCh; err != nil && downloadErr == nil {
+			downloadErr = err
+		}
+	}
+
+	if err := cmd.Wait(); err != nil && downloadErr == nil {
+		downloadErr = fmt.Errorf("ffmpeg: %w", err)
+	}
+
+	return downloadErr
+}
+
+// downloadFormatTo streams format's content through the existing chunked
+// download pipeline into w, closing w once the stream is fully written or an
+// error occurs.
+func (c *Client) downloadFormatTo(ctx context.Context, video *Video, format *Format, w *os.File) error {
+	defer w.Close()
+
+	sender := make(chan audioData)
+
+	if _, err := c.GetStreamContext(ctx, video, format, sender); err != nil {
+		return err
+	}
+
+	var received, total uint64
+	for total == 0 || received < total {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data, ok := <-sender:
+			if !ok {
+				return fmt.Errorf("youtube: download stream closed before receiving all chunks (%d/%d)", received, total)
+			}
+
+			total = data.totalChunks
+
+			if _, err := w.Write(data.data); err != nil {
+				return fmt.Errorf("write to ffmpeg: %w", err)
+			}
+
+			received++
+		}
+	}
+
+	return nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
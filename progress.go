@@ -0,0 +1,99 @@
+package youtube
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// ProgressFunc reports download progress. For downloadOnce it's invoked as
+// bytes are read from the response body (bytesDone is cumulative,
+// chunkIdx/totalChunks are always 1/1). For downloadChunk it's invoked once
+// per successfully downloaded range (bytesDone is that chunk's size).
+type ProgressFunc func(bytesDone, totalBytes int64, chunkIdx, totalChunks int)
+
+// progressCallback returns the ProgressFunc to use for a download, preferring
+// opts's per-call override over the client-wide default.
+func (c *Client) progressCallback(opts *DownloadOptions) ProgressFunc {
+	if opts != nil && opts.ProgressCallback != nil {
+		return opts.ProgressCallback
+	}
+
+	return c.ProgressCallback
+}
+
+// bandwidthLimiter lazily builds the shared rate.Limiter enforcing
+// Client.MaxBytesPerSecond across every downloadOnce/downloadChunk caller, so
+// concurrent chunk goroutines cap their aggregate throughput rather than each
+// getting their own allowance.
+func (c *Client) bandwidthLimiter() *rate.Limiter {
+	c.bwLimiterOnce.Do(func() {
+		if c.MaxBytesPerSecond > 0 {
+			c.bwLimiter = rate.NewLimiter(rate.Limit(c.MaxBytesPerSecond), int(c.MaxBytesPerSecond))
+		}
+	})
+
+	return c.bwLimiter
+}
+
+// rateLimitedReader wraps r, blocking each Read so the aggregate throughput
+// across every reader sharing limiter stays within its configured rate.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// WaitN errors instead of waiting when asked to wait for more than the
+	// limiter's burst in one go, so a single large Read (io.ReadAll grows
+	// its buffer well past most configured caps) must never hand the
+	// limiter more than that burst at once.
+	if burst := r.limiter.Burst(); burst > 0 && len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limiter.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// progressReader wraps r, invoking cb after every Read with the cumulative
+// bytes read so far.
+type progressReader struct {
+	r                     io.Reader
+	total                 int64
+	chunkIdx, totalChunks int
+	done                  int64
+	cb                    ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.done += int64(n)
+		r.cb(r.done, r.total, r.chunkIdx, r.totalChunks)
+	}
+
+	return n, err
+}
+
+// wrapBody applies bandwidth limiting and progress reporting to body, in
+// that order, skipping either wrapper that isn't configured.
+func wrapBody(ctx context.Context, body io.Reader, limiter *rate.Limiter, progress ProgressFunc, total int64, chunkIdx, totalChunks int) io.Reader {
+	if limiter != nil {
+		body = &rateLimitedReader{ctx: ctx, r: body, limiter: limiter}
+	}
+
+	if progress != nil {
+		body = &progressReader{r: body, total: total, chunkIdx: chunkIdx, totalChunks: totalChunks, cb: progress}
+	}
+
+	return body
+}
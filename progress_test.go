@@ -0,0 +1,26 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitedReaderBoundsReadsToBurst(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 8192)
+	limiter := rate.NewLimiter(rate.Limit(1024), 1024)
+
+	r := &rateLimitedReader{ctx: context.Background(), r: bytes.NewReader(data), limiter: limiter}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Errorf("ReadAll returned %d bytes, want %d", len(got), len(data))
+	}
+}